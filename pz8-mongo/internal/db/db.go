@@ -0,0 +1,33 @@
+// Package db wires up the Mongo client/database used by the rest of the
+// application.
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Deps holds the Mongo handles shared across repositories.
+type Deps struct {
+	Client   *mongo.Client
+	Database *mongo.Database
+}
+
+// ConnectMongo dials uri, verifies the connection, and returns a Deps bound
+// to dbName.
+func ConnectMongo(ctx context.Context, uri, dbName string) (*Deps, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return &Deps{
+		Client:   client,
+		Database: client.Database(dbName),
+	}, nil
+}
@@ -0,0 +1,187 @@
+// Package notes implements the note-taking storage layer on top of MongoDB.
+package notes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when a note lookup does not match any document.
+var ErrNotFound = errors.New("notes: not found")
+
+// ErrDeleted is returned by operations that require a live note when the
+// note they were given is soft-deleted.
+var ErrDeleted = errors.New("notes: note is deleted")
+
+// Note is the persisted representation of a single note.
+type Note struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Title     string             `bson:"title"`
+	Content   string             `bson:"content"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+
+	// DeletedAt marks a note as soft-deleted. It's absent (not merely
+	// zero) on live notes, which is what the partial unique index on
+	// title and the default ByID/List filters key off of.
+	DeletedAt *time.Time `bson:"deletedAt,omitempty"`
+	// Version counts the number of successful Updates, starting at 0.
+	Version int `bson:"version"`
+	// Revisions holds up to maxRevisions previous title/content
+	// snapshots, oldest first, pushed by Update.
+	Revisions []NoteRevision `bson:"revisions,omitempty"`
+	// TagIDs references tags (internal/tags.Tag) attached to this note.
+	TagIDs []primitive.ObjectID `bson:"tagIds,omitempty"`
+}
+
+// Repo is the Mongo-backed notes repository. The zero value is not usable;
+// construct one with NewRepo.
+type Repo struct {
+	col  *mongo.Collection
+	sess mongo.SessionContext
+}
+
+// NewRepo builds a Repo over database's "notes" collection, ensuring the
+// indexes the repository relies on exist.
+func NewRepo(database *mongo.Database) (*Repo, error) {
+	col := database.Collection("notes")
+
+	// Unique on title, but only for live notes: the partial filter lets a
+	// soft-deleted note's title be reused by a new one.
+	_, err := col.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "title", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": false}}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = col.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "title", Value: "text"},
+			{Key: "content", Value: "text"},
+		},
+		Options: options.Index().SetWeights(bson.D{
+			{Key: "title", Value: 10},
+			{Key: "content", Value: 1},
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repo{col: col}, nil
+}
+
+// InSession returns a shallow copy of r whose operations run inside sessCtx
+// instead of against the bare collection. The original Repo is left
+// untouched, so it's safe to keep using it outside the transaction.
+func (r *Repo) InSession(sessCtx mongo.SessionContext) *Repo {
+	return &Repo{col: r.col, sess: sessCtx}
+}
+
+// WithSession starts a Mongo session on r's client and runs fn inside a
+// transaction, retrying on transient transaction errors per the driver's
+// session.WithTransaction semantics. fn receives a SessionContext that can
+// be passed to r.InSession to route further repo calls through the same
+// transaction.
+func (r *Repo) WithSession(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := r.col.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// ctx returns the context operations should use: the repo's bound session
+// context if InSession was used to create it, otherwise the caller's ctx.
+func (r *Repo) ctx(ctx context.Context) context.Context {
+	if r.sess != nil {
+		return r.sess
+	}
+	return ctx
+}
+
+// Create inserts a new note with the given title and content.
+func (r *Repo) Create(ctx context.Context, title, content string) (Note, error) {
+	now := time.Now()
+	n := Note{
+		ID:        primitive.NewObjectID(),
+		Title:     title,
+		Content:   content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := r.col.InsertOne(r.ctx(ctx), n); err != nil {
+		return Note{}, err
+	}
+	return n, nil
+}
+
+// ByID fetches a note by its hex ObjectID, returning ErrNotFound if it
+// doesn't exist. Soft-deleted notes are excluded unless includeDeleted is
+// true.
+func (r *Repo) ByID(ctx context.Context, id string, includeDeleted bool) (Note, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Note{}, ErrNotFound
+	}
+
+	filter := bson.M{"_id": oid}
+	if !includeDeleted {
+		filter["deletedAt"] = bson.M{"$exists": false}
+	}
+
+	var n Note
+	err = r.col.FindOne(r.ctx(ctx), filter).Decode(&n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Note{}, ErrNotFound
+	}
+	if err != nil {
+		return Note{}, err
+	}
+	return n, nil
+}
+
+// List returns up to opts.Limit notes (after skipping opts.Skip) matching
+// opts.Search under opts.Mode: newest-first for SearchModeSubstring, or
+// best-match-first for SearchModeText. It's a convenience wrapper around
+// ListStream for callers that want the whole page as a slice; callers that
+// want to avoid materializing large result sets should use ListStream
+// directly.
+func (r *Repo) List(ctx context.Context, opts ListOptions) ([]Note, error) {
+	stream, err := r.ListStream(ctx, ListFilter{
+		Search:         opts.Search,
+		Mode:           opts.Mode,
+		Limit:          opts.Limit,
+		Skip:           opts.Skip,
+		IncludeDeleted: opts.IncludeDeleted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close(ctx)
+
+	var notes []Note
+	for stream.Next(ctx) {
+		notes = append(notes, stream.Note())
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -2,15 +2,20 @@ package notes
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"path/filepath"
     "runtime"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 	"log"
 
 	"example.com/pz8-mongo/internal/db"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"github.com/joho/godotenv"
 )
 
@@ -81,7 +86,7 @@ func TestCreateAndGet(t *testing.T) {
 	}
 
 	// Test ByID
-	got, err := r.ByID(ctx, created.ID.Hex())
+	got, err := r.ByID(ctx, created.ID.Hex(), false)
 	if err != nil {
 		t.Fatal("by id:", err)
 	}
@@ -124,7 +129,7 @@ func TestByID_NotFound(t *testing.T) {
 	}
 
 	// Пытаемся получить несуществующую запись
-	_, err = r.ByID(ctx, primitive.NewObjectID().Hex())
+	_, err = r.ByID(ctx, primitive.NewObjectID().Hex(), false)
 	if err != ErrNotFound {
 		t.Fatalf("want ErrNotFound, got %v", err)
 	}
@@ -201,7 +206,7 @@ func TestList(t *testing.T) {
 	}
 
 	// Test List all
-	all, err := r.List(ctx, "", 10, 0)
+	all, err := r.List(ctx, ListOptions{Limit: 10})
 	if err != nil {
 		t.Fatal("list all:", err)
 	}
@@ -215,7 +220,7 @@ func TestList(t *testing.T) {
 	}
 
 	// Test List with search - должен найти только "Note One" и "Note Two"
-	search, err := r.List(ctx, "Note", 10, 0)
+	search, err := r.List(ctx, ListOptions{Search: "Note", Limit: 10})
 	if err != nil {
 		t.Fatal("list search:", err)
 	}
@@ -241,4 +246,593 @@ func getTitles(notes []Note) []string {
 		titles[i] = n.Title
 	}
 	return titles
+}
+
+// TestWithSession_CommitsAtomically is an integration test: transactions
+// require a replica set, which the default standalone test Mongo isn't. It
+// only runs when MONGO_RS_URI points at one.
+func TestWithSession_CommitsAtomically(t *testing.T) {
+	rsURI := os.Getenv("MONGO_RS_URI")
+	if rsURI == "" {
+		t.Skip("MONGO_RS_URI not set, skipping transaction integration test")
+	}
+
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, rsURI, dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	// Создаём две заметки в рамках одной транзакции
+	err = r.WithSession(ctx, func(sessCtx mongo.SessionContext) error {
+		txr := r.InSession(sessCtx)
+		if _, err := txr.Create(ctx, "Tx Note One", "Content 1"); err != nil {
+			return err
+		}
+		if _, err := txr.Create(ctx, "Tx Note Two", "Content 2"); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("with session:", err)
+	}
+
+	all, err := r.List(ctx, ListOptions{Search: "Tx Note", Limit: 10})
+	if err != nil {
+		t.Fatal("list:", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("want 2 notes committed, got %d", len(all))
+	}
+}
+
+// TestWithSession_RollsBackOnError is an integration test: transactions
+// require a replica set, which the default standalone test Mongo isn't. It
+// only runs when MONGO_RS_URI points at one.
+func TestWithSession_RollsBackOnError(t *testing.T) {
+	rsURI := os.Getenv("MONGO_RS_URI")
+	if rsURI == "" {
+		t.Skip("MONGO_RS_URI not set, skipping transaction integration test")
+	}
+
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, rsURI, dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	wantErr := errors.New("boom")
+	err = r.WithSession(ctx, func(sessCtx mongo.SessionContext) error {
+		txr := r.InSession(sessCtx)
+		if _, err := txr.Create(ctx, "Rolled Back Note", "Content"); err != nil {
+			return err
+		}
+		return wantErr
+	})
+	if err == nil {
+		t.Fatal("expected error from aborted transaction")
+	}
+
+	all, err := r.List(ctx, ListOptions{Search: "Rolled Back", Limit: 10})
+	if err != nil {
+		t.Fatal("list:", err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("want 0 notes after rollback, got %d", len(all))
+	}
+}
+
+func TestListStream_KeysetPagination(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	var created []Note
+	for i := 0; i < 5; i++ {
+		n, err := r.Create(ctx, "Stream "+primitive.NewObjectID().Hex(), "Content")
+		if err != nil {
+			t.Fatal("create:", err)
+		}
+		created = append(created, n)
+	}
+
+	// Первая страница: 2 самые новые заметки
+	page1, err := r.ListStream(ctx, ListFilter{Limit: 2})
+	if err != nil {
+		t.Fatal("list stream page 1:", err)
+	}
+	var firstPage []Note
+	for page1.Next(ctx) {
+		firstPage = append(firstPage, page1.Note())
+	}
+	if err := page1.Err(); err != nil {
+		t.Fatal("page 1 iteration:", err)
+	}
+	if err := page1.Close(ctx); err != nil {
+		t.Fatal("page 1 close:", err)
+	}
+	if len(firstPage) != 2 {
+		t.Fatalf("want 2 notes in first page, got %d", len(firstPage))
+	}
+	if firstPage[0].ID != created[4].ID || firstPage[1].ID != created[3].ID {
+		t.Fatalf("want newest-first page, got %v", firstPage)
+	}
+
+	// Вторая страница: продолжаем после последней заметки первой страницы
+	page2, err := r.ListStream(ctx, ListFilter{Limit: 2, After: firstPage[1].ID})
+	if err != nil {
+		t.Fatal("list stream page 2:", err)
+	}
+	defer page2.Close(ctx)
+
+	var secondPage []Note
+	for page2.Next(ctx) {
+		secondPage = append(secondPage, page2.Note())
+	}
+	if err := page2.Err(); err != nil {
+		t.Fatal("page 2 iteration:", err)
+	}
+	if len(secondPage) != 2 {
+		t.Fatalf("want 2 notes in second page, got %d", len(secondPage))
+	}
+	if secondPage[0].ID != created[2].ID || secondPage[1].ID != created[1].ID {
+		t.Fatalf("want the page following page 1, got %v", secondPage)
+	}
+}
+
+func TestList_SearchModeText(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	seed := []struct {
+		title   string
+		content string
+	}{
+		{"Go Release Notes", "The go team shipped generics this release"},
+		{"Rust Release Notes", "The rust team shipped async traits"},
+		{"Go And Rust Comparison", "comparing go and rust tooling"},
+	}
+	for _, n := range seed {
+		if _, err := r.Create(ctx, n.title, n.content); err != nil {
+			t.Fatal("create:", err)
+		}
+	}
+
+	// Многословный запрос: термины объединяются через OR
+	multi, err := r.List(ctx, ListOptions{Search: "generics async", Mode: SearchModeText, Limit: 10})
+	if err != nil {
+		t.Fatal("list multi-word:", err)
+	}
+	if len(multi) != 2 {
+		t.Fatalf("want 2 notes matching 'generics' or 'async', got %d: %v", len(multi), getTitles(multi))
+	}
+
+	// Точная фраза
+	phrase, err := r.List(ctx, ListOptions{Search: `"async traits"`, Mode: SearchModeText, Limit: 10})
+	if err != nil {
+		t.Fatal("list phrase:", err)
+	}
+	if len(phrase) != 1 || phrase[0].Title != "Rust Release Notes" {
+		t.Fatalf("want only 'Rust Release Notes' for phrase query, got %v", getTitles(phrase))
+	}
+
+	// Отрицание: найти всё про go, но не про rust
+	negated, err := r.List(ctx, ListOptions{Search: "go -rust", Mode: SearchModeText, Limit: 10})
+	if err != nil {
+		t.Fatal("list negation:", err)
+	}
+	if len(negated) != 1 || negated[0].Title != "Go Release Notes" {
+		t.Fatalf("want only 'Go Release Notes' for 'go -rust', got %v", getTitles(negated))
+	}
+}
+
+func TestListStream_Highlight(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	_, err = r.Create(ctx, "Highlight Me", "some prefix text then the word generics appears here")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+
+	stream, err := r.ListStream(ctx, ListFilter{
+		Search:    "generics",
+		Mode:      SearchModeText,
+		Limit:     10,
+		Highlight: true,
+	})
+	if err != nil {
+		t.Fatal("list stream:", err)
+	}
+	defer stream.Close(ctx)
+
+	if !stream.Next(ctx) {
+		t.Fatal("expected a match, got none:", stream.Err())
+	}
+	if snippet := stream.Snippet(); !strings.Contains(snippet, "generics") {
+		t.Fatalf("want snippet to contain the matched term, got %q", snippet)
+	}
+}
+
+func TestUpdate_RecordsRevisionAndBumpsVersion(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	created, err := r.Create(ctx, "Original Title", "Original Content")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+
+	updated, err := r.Update(ctx, created.ID.Hex(), "New Title", "New Content")
+	if err != nil {
+		t.Fatal("update:", err)
+	}
+	if updated.Title != "New Title" || updated.Content != "New Content" {
+		t.Fatalf("want updated fields, got %+v", updated)
+	}
+	if updated.Version != 1 {
+		t.Fatalf("want version 1 after one update, got %d", updated.Version)
+	}
+
+	history, err := r.History(ctx, created.ID.Hex())
+	if err != nil {
+		t.Fatal("history:", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("want 1 revision, got %d", len(history))
+	}
+	if history[0].Title != "Original Title" || history[0].Content != "Original Content" {
+		t.Fatalf("want revision to hold the pre-update fields, got %+v", history[0])
+	}
+	if history[0].Version != 0 {
+		t.Fatalf("want revision tagged with the superseded version 0, got %d", history[0].Version)
+	}
+}
+
+func TestUpdate_ConcurrentUpdatesDontLoseRevisions(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	created, err := r.Create(ctx, "Concurrent Title", "Concurrent Content")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+
+	const updaters = 5
+	errs := make(chan error, updaters)
+	var wg sync.WaitGroup
+	for i := 0; i < updaters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := r.Update(ctx, created.ID.Hex(), fmt.Sprintf("Title %d", i), fmt.Sprintf("Content %d", i))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal("concurrent update:", err)
+		}
+	}
+
+	final, err := r.ByID(ctx, created.ID.Hex(), false)
+	if err != nil {
+		t.Fatal("by id:", err)
+	}
+	if final.Version != updaters {
+		t.Fatalf("want version %d after %d concurrent updates, got %d", updaters, updaters, final.Version)
+	}
+
+	history, err := r.History(ctx, created.ID.Hex())
+	if err != nil {
+		t.Fatal("history:", err)
+	}
+	if len(history) != updaters {
+		t.Fatalf("want %d revisions recorded, got %d", updaters, len(history))
+	}
+
+	// Каждая версия от 0 до updaters-1 должна быть записана ровно один раз
+	seen := make(map[int]int)
+	for _, rev := range history {
+		seen[rev.Version]++
+	}
+	for v := 0; v < updaters; v++ {
+		if seen[v] != 1 {
+			t.Fatalf("want version %d recorded exactly once, got %d times", v, seen[v])
+		}
+	}
+}
+
+func TestRestore_RewritesToHistoricalVersion(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	created, err := r.Create(ctx, "v0 Title", "v0 Content")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+	if _, err := r.Update(ctx, created.ID.Hex(), "v1 Title", "v1 Content"); err != nil {
+		t.Fatal("update to v1:", err)
+	}
+
+	restored, err := r.Restore(ctx, created.ID.Hex(), 0)
+	if err != nil {
+		t.Fatal("restore:", err)
+	}
+	if restored.Title != "v0 Title" || restored.Content != "v0 Content" {
+		t.Fatalf("want v0 fields restored, got %+v", restored)
+	}
+	// Restore не отматывает версию назад, а двигает её вперёд
+	if restored.Version != 2 {
+		t.Fatalf("want version to keep advancing, got %d", restored.Version)
+	}
+}
+
+func TestRestore_RejectsDeletedNote(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	created, err := r.Create(ctx, "v0 Title", "v0 Content")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+	if _, err := r.Update(ctx, created.ID.Hex(), "v1 Title", "v1 Content"); err != nil {
+		t.Fatal("update to v1:", err)
+	}
+	if err := r.Delete(ctx, created.ID.Hex()); err != nil {
+		t.Fatal("delete:", err)
+	}
+
+	if _, err := r.Restore(ctx, created.ID.Hex(), 0); err != ErrDeleted {
+		t.Fatalf("want ErrDeleted for a soft-deleted note, got %v", err)
+	}
+}
+
+func TestDelete_SoftDeletesAndFreesTitle(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	created, err := r.Create(ctx, "Soft Deleted Title", "Content")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+
+	if err := r.Delete(ctx, created.ID.Hex()); err != nil {
+		t.Fatal("delete:", err)
+	}
+
+	if _, err := r.ByID(ctx, created.ID.Hex(), false); err != ErrNotFound {
+		t.Fatalf("want ErrNotFound for deleted note, got %v", err)
+	}
+
+	withDeleted, err := r.ByID(ctx, created.ID.Hex(), true)
+	if err != nil {
+		t.Fatal("by id include deleted:", err)
+	}
+	if withDeleted.DeletedAt == nil {
+		t.Fatal("expected DeletedAt to be set")
+	}
+
+	all, err := r.List(ctx, ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatal("list:", err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("want deleted note excluded from List by default, got %d", len(all))
+	}
+
+	// Заголовок удалённой заметки можно переиспользовать
+	_, err = r.Create(ctx, "Soft Deleted Title", "New Content")
+	if err != nil {
+		t.Fatal("create with reused title:", err)
+	}
+}
+
+// TestWatch_EventSequence is an integration test: change streams require a
+// replica set, which the default standalone test Mongo isn't. It only runs
+// when MONGO_RS_URI points at one.
+func TestWatch_EventSequence(t *testing.T) {
+	rsURI := os.Getenv("MONGO_RS_URI")
+	if rsURI == "" {
+		t.Skip("MONGO_RS_URI not set, skipping change stream integration test")
+	}
+
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, rsURI, dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+
+	w, err := r.Watch(watchCtx, WatchOptions{Consumer: "test-consumer"})
+	if err != nil {
+		t.Fatal("watch:", err)
+	}
+	defer w.Close()
+
+	created, err := r.Create(ctx, "Watched Note", "Content")
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+	if _, err := r.Update(ctx, created.ID.Hex(), "Watched Note Updated", "Content"); err != nil {
+		t.Fatal("update:", err)
+	}
+	if err := r.Delete(ctx, created.ID.Hex()); err != nil {
+		t.Fatal("delete:", err)
+	}
+
+	wantTypes := []NoteEventType{NoteEventCreated, NoteEventUpdated, NoteEventDeleted}
+	for i, want := range wantTypes {
+		select {
+		case event, ok := <-w.Events():
+			if !ok {
+				t.Fatalf("event channel closed early at index %d: %v", i, w.Err())
+			}
+			if event.Type != want {
+				t.Fatalf("event %d: want type %s, got %s", i, want, event.Type)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxRevisions caps how many past title/content snapshots Update keeps on
+// a note.
+const maxRevisions = 20
+
+// NoteRevision is a title/content snapshot captured by Update just before
+// it overwrites them, tagged with the version it superseded.
+type NoteRevision struct {
+	Version   int       `bson:"version"`
+	Title     string    `bson:"title"`
+	Content   string    `bson:"content"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// Update changes title and content, recording the note's current
+// title/content/version as a new revision (capping the history at the
+// last maxRevisions entries, oldest first) and bumping version.
+//
+// The whole thing — capturing the pre-image as a revision, appending it,
+// and bumping version — is a single aggregation-pipeline FindOneAndUpdate,
+// so it's one atomic write: two concurrent Updates on the same note can't
+// interleave, and the revisions array keeps its oldest-first order no
+// matter how updates race.
+func (r *Repo) Update(ctx context.Context, id, title, content string) (Note, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Note{}, ErrNotFound
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"_prevRevision": bson.M{
+				"version":   "$version",
+				"title":     "$title",
+				"content":   "$content",
+				"updatedAt": "$updatedAt",
+			},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"title":     title,
+			"content":   content,
+			"updatedAt": time.Now(),
+			"version":   bson.M{"$add": bson.A{"$version", 1}},
+			"revisions": bson.M{"$slice": bson.A{
+				bson.M{"$concatArrays": bson.A{
+					bson.M{"$ifNull": bson.A{"$revisions", bson.A{}}},
+					bson.A{"$_prevRevision"},
+				}},
+				-maxRevisions,
+			}},
+		}}},
+		{{Key: "$unset", Value: "_prevRevision"}},
+	}
+
+	var updated Note
+	err = r.col.FindOneAndUpdate(r.ctx(ctx),
+		bson.M{"_id": oid, "deletedAt": bson.M{"$exists": false}},
+		pipeline,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Note{}, ErrNotFound
+	}
+	if err != nil {
+		return Note{}, err
+	}
+
+	return updated, nil
+}
+
+// Delete soft-deletes a note by stamping deletedAt, which excludes it from
+// ByID and List by default while leaving the document (and its revision
+// history) in place so it can still be looked up with IncludeDeleted.
+func (r *Repo) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	res, err := r.col.UpdateOne(r.ctx(ctx),
+		bson.M{"_id": oid, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// History returns the revisions recorded for note id, oldest first. It
+// looks the note up with IncludeDeleted semantics so history remains
+// available after a soft delete.
+func (r *Repo) History(ctx context.Context, id string) ([]NoteRevision, error) {
+	n, err := r.ByID(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	return n.Revisions, nil
+}
+
+// Restore rewrites note id's title/content back to the given historical
+// version. It's implemented as an Update to that version's title/content,
+// so the note's current state is itself pushed onto the history and the
+// version counter keeps advancing rather than rewinding. It returns
+// ErrDeleted for a soft-deleted note; Restore rewinds content, it doesn't
+// undelete.
+func (r *Repo) Restore(ctx context.Context, id string, version int) (Note, error) {
+	n, err := r.ByID(ctx, id, true)
+	if err != nil {
+		return Note{}, err
+	}
+	if n.DeletedAt != nil {
+		return Note{}, ErrDeleted
+	}
+
+	if n.Version == version {
+		return n, nil
+	}
+
+	for _, rev := range n.Revisions {
+		if rev.Version == version {
+			return r.Update(ctx, id, rev.Title, rev.Content)
+		}
+	}
+	return Note{}, ErrNotFound
+}
@@ -0,0 +1,97 @@
+package notes
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SearchMode selects how List/ListStream interpret a ListFilter's Search
+// string.
+type SearchMode int
+
+const (
+	// SearchModeSubstring matches titles against Search as a
+	// case-insensitive regular expression, so plain text matches as a
+	// substring. This is the original List behavior.
+	SearchModeSubstring SearchMode = iota
+	// SearchModeText runs Search through the notes collection's $text
+	// index over title and content, ranked by textScore. It understands
+	// the usual $text query syntax: multiple words are OR'd, "quoted
+	// phrases" are matched exactly, and -negated terms are excluded.
+	SearchModeText
+)
+
+// ListOptions configures List beyond the basic search/limit/skip triple:
+// which search mode to use. List has no way to return a snippet alongside
+// each Note, so it has no Highlight option; callers that need highlighted
+// excerpts should call ListStream directly with ListFilter.Highlight set
+// and read them off NoteStream.Snippet.
+type ListOptions struct {
+	Search string
+	Mode   SearchMode
+	Limit  int64
+	Skip   int64
+	// IncludeDeleted includes soft-deleted notes, which are excluded by
+	// default.
+	IncludeDeleted bool
+}
+
+const snippetRadius = 40
+
+// highlightSnippet returns a short window of content around the first
+// occurrence of one of query's positive terms, trimmed to snippetRadius
+// bytes on each side (widened to the nearest rune boundary). It's a
+// best-effort aid for displaying search
+// results, independent of $text's own relevance ranking.
+func highlightSnippet(content, query string) string {
+	lower := strings.ToLower(content)
+
+	pos := -1
+	for _, term := range searchTerms(query) {
+		if i := strings.Index(lower, term); i != -1 && (pos == -1 || i < pos) {
+			pos = i
+		}
+	}
+	if pos == -1 {
+		pos = 0
+	}
+
+	start := pos - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := pos + snippetRadius
+	if end > len(content) {
+		end = len(content)
+	}
+
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+
+	snippet := content[start:end]
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(content) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// searchTerms splits a $text-style query into its lowercased positive
+// terms, dropping -negated terms and the quotes around phrases.
+func searchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		term := strings.Trim(field, `"`)
+		if term == "" || strings.HasPrefix(term, "-") {
+			continue
+		}
+		terms = append(terms, strings.ToLower(term))
+	}
+	return terms
+}
@@ -0,0 +1,149 @@
+package notes
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListFilter describes a ListStream query. Pagination is normally
+// keyset-based: set After to the ID of the last note seen to fetch the next
+// page, rather than an offset. Skip is supported too, for callers (like
+// List) that need classic offset pagination or that use SearchModeText,
+// where ranking by score makes a stable keyset impractical.
+type ListFilter struct {
+	Search string
+	Mode   SearchMode
+	Limit  int64
+	Skip   int64
+
+	// After restricts results to notes with an _id less than this value.
+	// Combined with the descending _id sort, it yields the page following
+	// whatever note After identifies. Ignored when Mode is SearchModeText.
+	// The zero value fetches from the start.
+	After primitive.ObjectID
+
+	// Projection, if set, is merged into the projection passed to the
+	// underlying Find call.
+	Projection bson.M
+
+	// Highlight, if true, makes NoteStream.Snippet return a matched
+	// excerpt of each note's content.
+	Highlight bool
+
+	// IncludeDeleted includes soft-deleted notes, which are excluded by
+	// default.
+	IncludeDeleted bool
+}
+
+// NoteStream iterates over a MongoDB cursor one note at a time, without
+// materializing the whole result set in memory.
+type NoteStream struct {
+	cur       *mongo.Cursor
+	note      Note
+	err       error
+	query     string
+	highlight bool
+}
+
+// Next advances the stream to the next note, returning false once the
+// cursor is exhausted or an error occurs (check Err to tell which).
+func (s *NoteStream) Next(ctx context.Context) bool {
+	if !s.cur.Next(ctx) {
+		return false
+	}
+	// Decode leaves fields absent from the document untouched, so reset
+	// first to keep the previous note's fields from carrying over.
+	s.note = Note{}
+	if err := s.cur.Decode(&s.note); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+// Note returns the note most recently loaded by Next.
+func (s *NoteStream) Note() Note {
+	return s.note
+}
+
+// Err returns the first error encountered while iterating, if any.
+func (s *NoteStream) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.cur.Err()
+}
+
+// Close releases the underlying cursor. Callers must call it once done
+// iterating.
+func (s *NoteStream) Close(ctx context.Context) error {
+	return s.cur.Close(ctx)
+}
+
+// Snippet returns a highlighted excerpt of the current note's content
+// around the first matching search term, when the stream was opened with
+// Highlight. It returns "" otherwise.
+func (s *NoteStream) Snippet() string {
+	if !s.highlight {
+		return ""
+	}
+	return highlightSnippet(s.note.Content, s.query)
+}
+
+// ListStream runs filter against the notes collection and returns a
+// NoteStream for the caller to page through, instead of loading every
+// matching note into memory up front.
+func (r *Repo) ListStream(ctx context.Context, filter ListFilter) (*NoteStream, error) {
+	q := bson.M{}
+	if !filter.IncludeDeleted {
+		q["deletedAt"] = bson.M{"$exists": false}
+	}
+
+	sort := bson.D{{Key: "_id", Value: -1}}
+	projection := filter.Projection
+
+	switch filter.Mode {
+	case SearchModeText:
+		if filter.Search != "" {
+			q["$text"] = bson.M{"$search": filter.Search}
+		}
+		cloned := bson.M{}
+		for k, v := range projection {
+			cloned[k] = v
+		}
+		projection = cloned
+		projection["score"] = bson.M{"$meta": "textScore"}
+		sort = bson.D{
+			{Key: "score", Value: bson.M{"$meta": "textScore"}},
+			{Key: "createdAt", Value: -1},
+		}
+	default:
+		if filter.Search != "" {
+			q["title"] = bson.M{"$regex": filter.Search, "$options": "i"}
+		}
+		if !filter.After.IsZero() {
+			q["_id"] = bson.M{"$lt": filter.After}
+		}
+	}
+
+	opts := options.Find().SetSort(sort)
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	if filter.Skip > 0 {
+		opts.SetSkip(filter.Skip)
+	}
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+
+	cur, err := r.col.Find(r.ctx(ctx), q, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &NoteStream{cur: cur, query: filter.Search, highlight: filter.Highlight}, nil
+}
@@ -0,0 +1,101 @@
+package notes
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AndOr selects how ListByTag combines multiple tag IDs.
+type AndOr int
+
+const (
+	// Or matches notes carrying any of the given tags.
+	Or AndOr = iota
+	// And matches notes carrying all of the given tags.
+	And
+)
+
+// AddTag attaches tagID to note id, a no-op if it's already attached.
+func (r *Repo) AddTag(ctx context.Context, id string, tagID primitive.ObjectID) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	res, err := r.col.UpdateOne(r.ctx(ctx),
+		bson.M{"_id": oid, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$addToSet": bson.M{"tagIds": tagID}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveTag detaches tagID from note id, a no-op if it isn't attached.
+func (r *Repo) RemoveTag(ctx context.Context, id string, tagID primitive.ObjectID) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	res, err := r.col.UpdateOne(r.ctx(ctx),
+		bson.M{"_id": oid, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$pull": bson.M{"tagIds": tagID}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+var errEmptyTagIDs = errors.New("notes: ListByTag requires at least one tag id")
+
+// ListByTag returns up to limit notes (after skipping skip), newest first,
+// that carry any (mode Or) or all (mode And) of tagIDs.
+func (r *Repo) ListByTag(ctx context.Context, tagIDs []primitive.ObjectID, mode AndOr, limit, skip int64) ([]Note, error) {
+	if len(tagIDs) == 0 {
+		return nil, errEmptyTagIDs
+	}
+
+	op := "$in"
+	if mode == And {
+		op = "$all"
+	}
+
+	filter := bson.M{
+		"tagIds":    bson.M{op: tagIDs},
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+
+	cur, err := r.col.Find(r.ctx(ctx), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream := &NoteStream{cur: cur}
+	defer stream.Close(ctx)
+
+	var notes []Note
+	for stream.Next(ctx) {
+		notes = append(notes, stream.Note())
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -0,0 +1,192 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NoteEventType identifies what kind of change a NoteEvent describes.
+type NoteEventType string
+
+const (
+	NoteEventCreated NoteEventType = "created"
+	NoteEventUpdated NoteEventType = "updated"
+	NoteEventDeleted NoteEventType = "deleted"
+)
+
+// NoteEvent describes a single change observed on the notes collection.
+type NoteEvent struct {
+	Type        NoteEventType
+	Note        Note
+	ResumeToken bson.Raw
+}
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Consumer names this watcher for resume-token persistence: Watch
+	// stores the token of the last event it delivered in the
+	// _changestream_state collection under this name, and resumes from
+	// it on the next call unless ResumeAfter overrides that.
+	Consumer string
+	// ResumeAfter, if set, resumes the stream from this token instead of
+	// whatever was last persisted for Consumer.
+	ResumeAfter bson.Raw
+}
+
+// changeStreamState is the document persisted per consumer in the
+// _changestream_state collection.
+type changeStreamState struct {
+	Consumer string   `bson:"_id"`
+	Token    bson.Raw `bson:"token"`
+}
+
+// Watcher streams NoteEvents from the change stream opened by Watch.
+type Watcher struct {
+	events chan NoteEvent
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// Events returns the channel NoteEvents are delivered on. It's closed once
+// the watch ends, whether by context cancellation, Close, or a
+// non-resumable stream error; check Err afterwards to tell which.
+func (w *Watcher) Events() <-chan NoteEvent {
+	return w.events
+}
+
+// Err returns the error that ended the watch, if it wasn't simply the
+// context being canceled or Close being called.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Watcher) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+// Close stops the watch and releases the underlying change stream.
+func (w *Watcher) Close() {
+	w.cancel()
+}
+
+// Watch opens a change stream on the notes collection (this requires the
+// database to be a replica set) and fans out NoteEvents as they happen, one
+// goroutine per call. Unless opts.ResumeAfter is set, it resumes from the
+// token last persisted for opts.Consumer so a consumer can pick back up
+// after a crash. A token is persisted only after its event has been sent
+// on the channel, so an event delivered just before a crash may be
+// delivered again on resume.
+func (r *Repo) Watch(ctx context.Context, opts WatchOptions) (*Watcher, error) {
+	if opts.Consumer == "" {
+		return nil, errors.New("notes: Watch requires a Consumer name")
+	}
+
+	stateCol := r.col.Database().Collection("_changestream_state")
+
+	resumeAfter := opts.ResumeAfter
+	if resumeAfter == nil {
+		var state changeStreamState
+		err := stateCol.FindOne(ctx, bson.M{"_id": opts.Consumer}).Decode(&state)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		resumeAfter = state.Token
+	}
+
+	csOpts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeAfter != nil {
+		csOpts.SetResumeAfter(resumeAfter)
+	}
+
+	cs, err := r.col.Watch(ctx, mongo.Pipeline{}, csOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	w := &Watcher{
+		events: make(chan NoteEvent),
+		cancel: cancel,
+	}
+
+	go r.runWatch(watchCtx, cs, stateCol, opts.Consumer, w)
+
+	return w, nil
+}
+
+func (r *Repo) runWatch(ctx context.Context, cs *mongo.ChangeStream, stateCol *mongo.Collection, consumer string, w *Watcher) {
+	defer close(w.events)
+	defer cs.Close(context.Background())
+
+	for cs.Next(ctx) {
+		var decoded struct {
+			OperationType     string `bson:"operationType"`
+			FullDocument      Note   `bson:"fullDocument"`
+			UpdateDescription struct {
+				UpdatedFields bson.M `bson:"updatedFields"`
+			} `bson:"updateDescription"`
+		}
+		if err := cs.Decode(&decoded); err != nil {
+			w.setErr(err)
+			return
+		}
+
+		var eventType NoteEventType
+		switch decoded.OperationType {
+		case "insert":
+			eventType = NoteEventCreated
+		case "update", "replace":
+			// Repo.Delete is a soft delete (an update that sets
+			// deletedAt), so it surfaces here as an update op rather
+			// than a real "delete" one. Treat it as a NoteEventDeleted
+			// so consumers can tell a tombstone from a content edit.
+			// The check reads the event's own updatedFields rather than
+			// fullDocument: with UpdateLookup, fullDocument is the note
+			// as it is when the event is read, so an edit followed
+			// quickly by a delete would otherwise look like a delete.
+			if _, ok := decoded.UpdateDescription.UpdatedFields["deletedAt"]; ok {
+				eventType = NoteEventDeleted
+			} else {
+				eventType = NoteEventUpdated
+			}
+		case "delete":
+			eventType = NoteEventDeleted
+		default:
+			continue
+		}
+
+		token := cs.ResumeToken()
+		event := NoteEvent{Type: eventType, Note: decoded.FullDocument, ResumeToken: token}
+
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return
+		}
+
+		_, err := stateCol.UpdateOne(context.Background(),
+			bson.M{"_id": consumer},
+			bson.M{"$set": bson.M{"token": token}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			w.setErr(err)
+			return
+		}
+	}
+
+	if err := cs.Err(); err != nil && ctx.Err() == nil {
+		w.setErr(err)
+	}
+}
@@ -0,0 +1,69 @@
+package tags
+
+import (
+	"context"
+	"regexp"
+
+	"example.com/pz8-mongo/internal/notes"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultKindName is the TagKind MigrateHashtags files discovered hashtags
+// under.
+const DefaultKindName = "Hashtag"
+
+// hashtagPattern matches a #token. The character class is spelled out
+// with \p{L}/\p{N} rather than \w, which in Go's regexp is ASCII-only and
+// would silently drop non-Latin hashtags (e.g. Cyrillic) instead of
+// migrating them.
+var hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// MigrateHashtags scans every note in notesRepo that isn't soft-deleted
+// (List excludes those) for #hashtag tokens in its
+// content, creates a tag under the default kind for each distinct hashtag
+// seen (reusing one if it already exists), and backfills the note's
+// TagIDs. It returns the number of notes that had tags backfilled.
+func MigrateHashtags(ctx context.Context, notesRepo *notes.Repo, tagsRepo *Repo) (int, error) {
+	kind, err := tagsRepo.EnsureKind(ctx, DefaultKindName, "#808080", "Hashtags")
+	if err != nil {
+		return 0, err
+	}
+
+	all, err := notesRepo.List(ctx, notes.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, n := range all {
+		matches := hashtagPattern.FindAllStringSubmatch(n.Content, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		seen := make(map[string]bool, len(matches))
+		var tagIDs []primitive.ObjectID
+		for _, m := range matches {
+			name := m[1]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			tag, err := tagsRepo.EnsureTag(ctx, kind.ID, name)
+			if err != nil {
+				return updated, err
+			}
+			tagIDs = append(tagIDs, tag.ID)
+		}
+
+		for _, tagID := range tagIDs {
+			if err := notesRepo.AddTag(ctx, n.ID.Hex(), tagID); err != nil {
+				return updated, err
+			}
+		}
+		updated++
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,195 @@
+// Package tags implements a tagging subsystem for notes: tags grouped into
+// kinds (e.g. "Hashtag", "Category"), each kind rendering with its own
+// color and plural label.
+package tags
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when a tag or kind lookup does not match any
+// document.
+var ErrNotFound = errors.New("tags: not found")
+
+// ErrDuplicateSlug is returned when creating a tag whose slug already
+// exists under the same kind.
+var ErrDuplicateSlug = errors.New("tags: slug already exists for this kind")
+
+// Tag is a single label, scoped to a Kind, attachable to notes via
+// notes.Note.TagIDs.
+type Tag struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Kind primitive.ObjectID `bson:"kind"`
+	Name string             `bson:"name"`
+	Slug string             `bson:"slug"`
+}
+
+// TagKind groups tags that share a presentation: a color and a plural
+// label (e.g. "Hashtag" / "#808080" / "Hashtags").
+type TagKind struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Name   string             `bson:"name"`
+	Color  string             `bson:"color"`
+	Plural string             `bson:"plural"`
+}
+
+// Repo is the Mongo-backed tags repository. The zero value is not usable;
+// construct one with NewRepo.
+type Repo struct {
+	tags  *mongo.Collection
+	kinds *mongo.Collection
+}
+
+// NewRepo builds a Repo over database's "tags" and "tagKinds" collections,
+// ensuring the indexes it relies on exist.
+func NewRepo(database *mongo.Database) (*Repo, error) {
+	tagsCol := database.Collection("tags")
+	kindsCol := database.Collection("tagKinds")
+
+	_, err := tagsCol.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "kind", Value: 1},
+			{Key: "slug", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = kindsCol.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repo{
+		tags:  tagsCol,
+		kinds: kindsCol,
+	}, nil
+}
+
+// CreateKind inserts a new TagKind.
+func (r *Repo) CreateKind(ctx context.Context, name, color, plural string) (TagKind, error) {
+	k := TagKind{
+		ID:     primitive.NewObjectID(),
+		Name:   name,
+		Color:  color,
+		Plural: plural,
+	}
+	if _, err := r.kinds.InsertOne(ctx, k); err != nil {
+		return TagKind{}, err
+	}
+	return k, nil
+}
+
+// EnsureKind returns the TagKind named name, creating it with the given
+// color/plural if it doesn't exist yet. It's an atomic upsert keyed on the
+// unique index on name, so concurrent callers ensuring the same kind all
+// land on the same document instead of racing a find with an insert.
+func (r *Repo) EnsureKind(ctx context.Context, name, color, plural string) (TagKind, error) {
+	var k TagKind
+	err := r.kinds.FindOneAndUpdate(ctx,
+		bson.M{"name": name},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":    primitive.NewObjectID(),
+			"name":   name,
+			"color":  color,
+			"plural": plural,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&k)
+	if err != nil {
+		return TagKind{}, err
+	}
+	return k, nil
+}
+
+// CreateTag inserts a new tag under kindID, slugifying name. It returns
+// ErrDuplicateSlug if kindID already has a tag with the resulting slug.
+func (r *Repo) CreateTag(ctx context.Context, kindID primitive.ObjectID, name string) (Tag, error) {
+	t := Tag{
+		ID:   primitive.NewObjectID(),
+		Kind: kindID,
+		Name: name,
+		Slug: slugify(name),
+	}
+
+	_, err := r.tags.InsertOne(ctx, t)
+	if mongo.IsDuplicateKeyError(err) {
+		return Tag{}, ErrDuplicateSlug
+	}
+	if err != nil {
+		return Tag{}, err
+	}
+	return t, nil
+}
+
+// EnsureTag returns the tag named name under kindID, creating it if it
+// doesn't exist yet. It's an atomic upsert keyed on the unique (kind, slug)
+// index, so concurrent callers ensuring the same tag all land on the same
+// document instead of racing a find with an insert.
+func (r *Repo) EnsureTag(ctx context.Context, kindID primitive.ObjectID, name string) (Tag, error) {
+	slug := slugify(name)
+
+	var t Tag
+	err := r.tags.FindOneAndUpdate(ctx,
+		bson.M{"kind": kindID, "slug": slug},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":  primitive.NewObjectID(),
+			"kind": kindID,
+			"name": name,
+			"slug": slug,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&t)
+	if err != nil {
+		return Tag{}, err
+	}
+	return t, nil
+}
+
+// ByID fetches a tag by its hex ObjectID, returning ErrNotFound if it
+// doesn't exist.
+func (r *Repo) ByID(ctx context.Context, id string) (Tag, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Tag{}, ErrNotFound
+	}
+
+	var t Tag
+	err = r.tags.FindOne(ctx, bson.M{"_id": oid}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Tag{}, ErrNotFound
+	}
+	if err != nil {
+		return Tag{}, err
+	}
+	return t, nil
+}
+
+// slugPattern matches runs of characters that aren't Unicode letters or
+// numbers. It's deliberately not ASCII-only: an [^a-z0-9]+ pattern would
+// strip an all-Cyrillic (or other non-Latin) name down to "", making every
+// such name collide on the empty slug.
+var slugPattern = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// slugify lowercases name and collapses runs of non-alphanumeric
+// characters into a single hyphen, trimming leading/trailing hyphens.
+// strings.ToLower and slugPattern are both Unicode-aware, so non-Latin
+// names (e.g. Cyrillic) slugify to distinct, non-empty slugs rather than
+// being discarded.
+func slugify(name string) string {
+	slug := slugPattern.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}
@@ -0,0 +1,296 @@
+package tags
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"example.com/pz8-mongo/internal/db"
+	"example.com/pz8-mongo/internal/notes"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	_, filename, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(filename), "../..")
+	envPath := filepath.Join(dir, ".env")
+
+	if err := godotenv.Load(envPath); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+}
+
+func getTestMongoURI() string {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	return uri
+}
+
+func TestCreateTag(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	kind, err := r.CreateKind(ctx, "Category", "#00ff00", "Categories")
+	if err != nil {
+		t.Fatal("create kind:", err)
+	}
+
+	tag, err := r.CreateTag(ctx, kind.ID, "Go Tutorials")
+	if err != nil {
+		t.Fatal("create tag:", err)
+	}
+	if tag.ID.IsZero() {
+		t.Fatal("expected ID to be set")
+	}
+	if tag.Slug != "go-tutorials" {
+		t.Fatalf("want slug 'go-tutorials', got '%s'", tag.Slug)
+	}
+
+	got, err := r.ByID(ctx, tag.ID.Hex())
+	if err != nil {
+		t.Fatal("by id:", err)
+	}
+	if got.Name != "Go Tutorials" {
+		t.Fatalf("want name 'Go Tutorials', got '%s'", got.Name)
+	}
+}
+
+func TestCreateTag_DuplicateSlugRejected(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	kind, err := r.CreateKind(ctx, "Category", "#00ff00", "Categories")
+	if err != nil {
+		t.Fatal("create kind:", err)
+	}
+
+	if _, err := r.CreateTag(ctx, kind.ID, "Golang"); err != nil {
+		t.Fatal("create first tag:", err)
+	}
+
+	// Разные заголовки, но один и тот же slug
+	_, err = r.CreateTag(ctx, kind.ID, "golang")
+	if err != ErrDuplicateSlug {
+		t.Fatalf("want ErrDuplicateSlug, got %v", err)
+	}
+
+	// Тот же slug, но другой kind - должен пройти
+	otherKind, err := r.CreateKind(ctx, "Topic", "#0000ff", "Topics")
+	if err != nil {
+		t.Fatal("create other kind:", err)
+	}
+	if _, err := r.CreateTag(ctx, otherKind.ID, "Golang"); err != nil {
+		t.Fatal("create tag under different kind:", err)
+	}
+}
+
+func TestCreateTag_NonLatinNamesGetDistinctSlugs(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	r, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new repo:", err)
+	}
+
+	kind, err := r.CreateKind(ctx, "Category", "#00ff00", "Categories")
+	if err != nil {
+		t.Fatal("create kind:", err)
+	}
+
+	// Два разных русскоязычных названия не должны схлопываться в один
+	// и тот же (пустой) slug.
+	golang, err := r.CreateTag(ctx, kind.ID, "Голанг")
+	if err != nil {
+		t.Fatal("create first tag:", err)
+	}
+	if golang.Slug == "" {
+		t.Fatal("want non-empty slug for a non-Latin name")
+	}
+
+	mongo, err := r.CreateTag(ctx, kind.ID, "Монго")
+	if err != nil {
+		t.Fatal("create second tag:", err)
+	}
+	if mongo.Slug == golang.Slug {
+		t.Fatalf("want distinct slugs, got '%s' for both", mongo.Slug)
+	}
+}
+
+func TestListByTag_AndSearch(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	tagsRepo, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new tags repo:", err)
+	}
+	notesRepo, err := notes.NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new notes repo:", err)
+	}
+
+	kind, err := tagsRepo.CreateKind(ctx, "Category", "#00ff00", "Categories")
+	if err != nil {
+		t.Fatal("create kind:", err)
+	}
+	goTag, err := tagsRepo.CreateTag(ctx, kind.ID, "Go")
+	if err != nil {
+		t.Fatal("create go tag:", err)
+	}
+	mongoTag, err := tagsRepo.CreateTag(ctx, kind.ID, "Mongo")
+	if err != nil {
+		t.Fatal("create mongo tag:", err)
+	}
+
+	both, err := notesRepo.Create(ctx, "Go + Mongo Note", "Content")
+	if err != nil {
+		t.Fatal("create note:", err)
+	}
+	goOnly, err := notesRepo.Create(ctx, "Go Only Note", "Content")
+	if err != nil {
+		t.Fatal("create note:", err)
+	}
+
+	if err := notesRepo.AddTag(ctx, both.ID.Hex(), goTag.ID); err != nil {
+		t.Fatal("add tag:", err)
+	}
+	if err := notesRepo.AddTag(ctx, both.ID.Hex(), mongoTag.ID); err != nil {
+		t.Fatal("add tag:", err)
+	}
+	if err := notesRepo.AddTag(ctx, goOnly.ID.Hex(), goTag.ID); err != nil {
+		t.Fatal("add tag:", err)
+	}
+
+	// OR: оба тега должны вернуть обе заметки
+	orResults, err := notesRepo.ListByTag(ctx, []primitive.ObjectID{goTag.ID, mongoTag.ID}, notes.Or, 10, 0)
+	if err != nil {
+		t.Fatal("list by tag or:", err)
+	}
+	if len(orResults) != 2 {
+		t.Fatalf("want 2 notes for OR search, got %d", len(orResults))
+	}
+
+	// AND: только заметка с обоими тегами
+	andResults, err := notesRepo.ListByTag(ctx, []primitive.ObjectID{goTag.ID, mongoTag.ID}, notes.And, 10, 0)
+	if err != nil {
+		t.Fatal("list by tag and:", err)
+	}
+	if len(andResults) != 1 || andResults[0].ID != both.ID {
+		t.Fatalf("want only the note with both tags, got %d notes", len(andResults))
+	}
+}
+
+func TestMigrateHashtags(t *testing.T) {
+	ctx := context.Background()
+
+	dbName := "pz8_test_" + primitive.NewObjectID().Hex()
+	deps, err := db.ConnectMongo(ctx, getTestMongoURI(), dbName)
+	if err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Cleanup(func() {
+		deps.Database.Drop(ctx)
+		deps.Client.Disconnect(ctx)
+	})
+
+	tagsRepo, err := NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new tags repo:", err)
+	}
+	notesRepo, err := notes.NewRepo(deps.Database)
+	if err != nil {
+		t.Fatal("new notes repo:", err)
+	}
+
+	n, err := notesRepo.Create(ctx, "Hashtag Note", "Working on #golang and #mongodb today")
+	if err != nil {
+		t.Fatal("create note:", err)
+	}
+	// Нелатинский (кириллический) хэштег не должен молча отбрасываться.
+	ru, err := notesRepo.Create(ctx, "Russian Hashtag Note", "Сегодня разбираемся с #базыданных")
+	if err != nil {
+		t.Fatal("create note:", err)
+	}
+
+	updated, err := MigrateHashtags(ctx, notesRepo, tagsRepo)
+	if err != nil {
+		t.Fatal("migrate:", err)
+	}
+	if updated != 2 {
+		t.Fatalf("want 2 notes updated, got %d", updated)
+	}
+
+	got, err := notesRepo.ByID(ctx, n.ID.Hex(), false)
+	if err != nil {
+		t.Fatal("by id:", err)
+	}
+	if len(got.TagIDs) != 2 {
+		t.Fatalf("want 2 tag ids backfilled, got %d", len(got.TagIDs))
+	}
+
+	gotRU, err := notesRepo.ByID(ctx, ru.ID.Hex(), false)
+	if err != nil {
+		t.Fatal("by id:", err)
+	}
+	if len(gotRU.TagIDs) != 1 {
+		t.Fatalf("want 1 tag id backfilled for the Cyrillic hashtag, got %d", len(gotRU.TagIDs))
+	}
+}